@@ -0,0 +1,77 @@
+package apigateway
+
+import (
+	"context"
+	"errors"
+
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/juju/ratelimit"
+)
+
+// VaultValidateCircuit is the named circuit protecting apigateway's outbound
+// call into vault to validate a login's password.
+const VaultValidateCircuit = "vault.Validate"
+
+// ErrCircuitOpen is returned to callers when a circuit is open, rejects or
+// times out a call, or a request was throttled by the rate limiter, so
+// HandleLoginPost can map it to a 503.
+var ErrCircuitOpen = errors.New("vault is unavailable, try again later")
+
+// vaultLimiter throttles apigateway's outbound calls into vault to 100
+// requests/second before they ever reach a circuit, shared across every
+// endpoint wrapped with CircuitBreakerMiddleware.
+var vaultLimiter = ratelimit.NewBucketWithRate(100, 100)
+
+func init() {
+	cfg := hystrix.CommandConfig{
+		Timeout:                1000,
+		MaxConcurrentRequests:  100,
+		ErrorPercentThreshold:  25,
+		RequestVolumeThreshold: 20,
+		SleepWindow:            5000,
+	}
+	hystrix.ConfigureCommand(VaultValidateCircuit, cfg)
+}
+
+// hystrixStreamHandler serves the Hystrix metrics stream so operators can
+// watch circuit trips alongside the /metrics Prometheus endpoint.
+var hystrixStreamHandler = hystrix.NewStreamHandler()
+
+func init() {
+	hystrixStreamHandler.Start()
+}
+
+// CircuitBreakerMiddleware wraps an outbound go-kit endpoint (a call into
+// vault) with a named Hystrix circuit and a token-bucket rate limiter. Once
+// the circuit trips or a caller is throttled, the wrapped endpoint returns
+// ErrCircuitOpen instead of piling up on a failing downstream.
+func CircuitBreakerMiddleware(circuitName string, limiter *ratelimit.Bucket) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			if limiter.TakeAvailable(1) == 0 {
+				return nil, ErrCircuitOpen
+			}
+			var response interface{}
+			// no fallback: hystrix.Do then returns the run error as is,
+			// whereas an error returned from a fallback comes back wrapped
+			err := hystrix.Do(circuitName, func() error {
+				resp, err := next(ctx, request)
+				if err != nil {
+					return err
+				}
+				response = resp
+				return nil
+			}, nil)
+			// only hystrix's own rejections (open circuit, max concurrency,
+			// timeout) get mapped to ErrCircuitOpen; a plain error from next()
+			// is returned unchanged so callers can tell "vault is unavailable"
+			// apart from "vault rejected this request"
+			switch err {
+			case hystrix.ErrCircuitOpen, hystrix.ErrMaxConcurrency, hystrix.ErrTimeout:
+				return nil, ErrCircuitOpen
+			}
+			return response, err
+		}
+	}
+}
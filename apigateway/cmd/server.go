@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"github.com/grpc-ecosystem/go-grpc-middleware/tracing/opentracing"
+	"github.com/julienschmidt/httprouter"
+	"github.com/opentracing/opentracing-go"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	flag "github.com/spf13/pflag"
+	"google.golang.org/grpc"
+	"github.com/farhadf/micromovies2/apigateway"
+	"github.com/farhadf/micromovies2/pkg/tracing"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+func main() {
+	var (
+		httpAddr     string
+		vaultAddr    string
+		console      bool
+		drainTimeout time.Duration
+	)
+	flag.StringVarP(&httpAddr, "http", "H", ":8080", "http listen address")
+	flag.StringVarP(&vaultAddr, "vault", "V", ":8085", "vault gRPC address")
+	flag.BoolVarP(&console, "console", "c", false, "turns on pretty console logging")
+	flag.DurationVar(&drainTimeout, "drain-timeout", 10*time.Second, "time allowed to drain in-flight requests before shutdown")
+	jaegerConfig := tracing.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	//zerolog
+	logger := zerolog.New(os.Stderr).With().Timestamp().Caller().Logger()
+	//console pretty printing
+	if console {
+		logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+	}
+	errChan := make(chan error, 1)
+	//os signal handling; canceling ctx lets everything downstream observe shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	//tracing
+	tracer, closer := tracing.Init("apigatewayService", jaegerConfig)
+	opentracing.SetGlobalTracer(tracer)
+	span := tracer.StartSpan("server")
+
+	//dial vault with the grpc_opentracing client interceptor so every RPC
+	//picks the active span straight off ctx and injects it into the outbound
+	//gRPC metadata, instead of hand-rolling header propagation
+	conn, err := grpc.Dial(vaultAddr, grpc.WithInsecure(), grpc.WithUnaryInterceptor(grpc_opentracing.UnaryClientInterceptor(grpc_opentracing.WithTracer(tracer))))
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to dial vault")
+	}
+	defer conn.Close()
+
+	endpoints := apigateway.NewEndpoints(ctx, conn).WithVaultProtection(tracer)
+	//httprouter initialization
+	router := httprouter.New()
+	endpoints.Register(router)
+	httpServer := &http.Server{Addr: httpAddr, Handler: router}
+	go func() {
+		errChan <- httpServer.ListenAndServe()
+	}()
+
+	logger.Info().Msg(httpAddr)
+	select {
+	case err := <-errChan:
+		logger.Error().Err(err).Msg("server error, shutting down")
+	case sig := <-sigChan:
+		logger.Info().Str("signal", sig.String()).Msg("received shutdown signal")
+	}
+	cancel()
+
+	//drain in-flight requests before tearing anything else down
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer shutdownCancel()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			logger.Error().Err(err).Msg("http server shutdown error")
+		}
+	}()
+	wg.Wait()
+
+	//finish the top-level span before closing the reporter it flushes through
+	span.Finish()
+	closer.Close()
+	logger.Info().Msg("shutdown complete")
+}
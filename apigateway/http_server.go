@@ -5,23 +5,43 @@ import (
 	"net/http"
 	"encoding/json"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
 )
 
 //using http router, register func will do the routing path registration
 func (e Endpoints) Register(r *httprouter.Router) {
-	r.Handle("POST", "/v1/login", e.HandleLoginPost)
-	r.Handler("GET", "/metrics", promttp.Handler())
+	r.Handle("POST", "/v1/login", instrument("/v1/login", "POST", e.HandleLoginPost))
+	r.Handler("GET", "/metrics", promhttp.Handler())
+	//hystrix stream lets operators watch the vault.Validate circuit trip
+	r.Handler("GET", "/hystrix.stream", hystrixStreamHandler)
 }
 
 //each method needs a http handler handlers are registered in the register func
 func (e Endpoints) HandleLoginPost(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
-	decodedLoginReq, err := decodeLoginRequest(e.Ctx, r)
+	//extract any span carried in the request headers so this handler's span
+	//connects to the caller's trace, and start a new one if there isn't one
+	tracer := opentracing.GlobalTracer()
+	spanCtx, _ := tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(r.Header))
+	span := tracer.StartSpan("HTTP POST /v1/login", ext.RPCServerOption(spanCtx))
+	defer span.Finish()
+	ctx := opentracing.ContextWithSpan(e.Ctx, span)
+
+	decodedLoginReq, err := decodeLoginRequest(ctx, r)
 	if err != nil {
+		ext.Error.Set(span, true)
 		respondError(w, 500, err)
 		return
 	}
-	resp, err := e.LoginEndpoint(e.Ctx, decodedLoginReq.(loginRequest))
+	//e.LoginEndpoint already carries tracing and circuit-breaker protection
+	//from Endpoints.WithVaultProtection, built once at startup
+	resp, err := e.LoginEndpoint(ctx, decodedLoginReq.(loginRequest))
 	if err != nil {
+		ext.Error.Set(span, true)
+		if err == ErrCircuitOpen {
+			respondError(w, http.StatusServiceUnavailable, err)
+			return
+		}
 		respondError(w, 500, err)
 		return
 	}
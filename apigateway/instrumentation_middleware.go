@@ -0,0 +1,56 @@
+package apigateway
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	requestCount = stdprometheus.NewCounterVec(stdprometheus.CounterOpts{
+		Namespace: "my_group",
+		Subsystem: "apigateway",
+		Name:      "request_count",
+		Help:      "Number of HTTP requests received.",
+	}, []string{"route", "method", "status_code"})
+
+	requestDuration = stdprometheus.NewHistogramVec(stdprometheus.HistogramOpts{
+		Namespace: "my_group",
+		Subsystem: "apigateway",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of HTTP requests in seconds.",
+	}, []string{"route", "method", "status_code"})
+)
+
+func init() {
+	stdprometheus.MustRegister(requestCount, requestDuration)
+}
+
+// statusRecorder delegates to an http.ResponseWriter while capturing the
+// status code, since httprouter.Handle has no other way to observe it.
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.statusCode = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// instrument wraps handle so every call is recorded as a request_count and
+// request_duration_seconds observation labeled by the httprouter route
+// pattern (not the raw URL, to avoid cardinality explosion) and method.
+func instrument(route, method string, handle httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		recorder := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		begin := time.Now()
+		handle(recorder, r, ps)
+		status := strconv.Itoa(recorder.statusCode)
+		requestCount.WithLabelValues(route, method, status).Inc()
+		requestDuration.WithLabelValues(route, method, status).Observe(time.Since(begin).Seconds())
+	}
+}
@@ -0,0 +1,40 @@
+package apigateway
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+// TracingMiddleware mirrors vault's endpoint tracing middleware: it starts a
+// child span named after method around the wrapped endpoint (e.g.
+// LoginEndpoint) and tags it with the outcome. The span is carried on ctx,
+// so the grpc_opentracing.UnaryClientInterceptor the vault client is dialed
+// with (see cmd/server.go) picks it straight off ctx and injects it into the
+// outbound gRPC metadata, connecting the trace api-gateway -> vault.
+func TracingMiddleware(tracer opentracing.Tracer, method string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			span, ctx := opentracing.StartSpanFromContextWithTracer(ctx, tracer, method)
+			defer span.Finish()
+			span.SetTag("method", method)
+			response, err := next(ctx, request)
+			if err != nil {
+				span.SetTag("error", true)
+				span.LogKV("event", "error", "message", err.Error())
+			}
+			return response, err
+		}
+	}
+}
+
+// WithVaultProtection wraps e.LoginEndpoint once, at startup, with the same
+// tracing middleware vault's own endpoints get in vault/cmd/server.go and,
+// outside it, the vault.Validate circuit-breaker/rate-limit middleware,
+// instead of rebuilding the chain per request.
+func (e Endpoints) WithVaultProtection(tracer opentracing.Tracer) Endpoints {
+	e.LoginEndpoint = TracingMiddleware(tracer, "Login")(e.LoginEndpoint)
+	e.LoginEndpoint = CircuitBreakerMiddleware(VaultValidateCircuit, vaultLimiter)(e.LoginEndpoint)
+	return e
+}
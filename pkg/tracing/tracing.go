@@ -0,0 +1,97 @@
+// Package tracing centralizes Jaeger tracer setup so every service's
+// main.go configures sampling and reporting the same way instead of each
+// hard-coding its own config.Configuration.
+package tracing
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	flag "github.com/spf13/pflag"
+	jaeger "github.com/uber/jaeger-client-go"
+	"github.com/uber/jaeger-client-go/config"
+)
+
+// Config holds the Jaeger settings a service exposes as flags. String
+// fields left at "" defer to the JAEGER_* environment variables read by
+// config.FromEnv() in Init. SamplerParam and LogSpans can legitimately be
+// set to their zero value, so Init instead checks fs.Changed to decide
+// whether they were explicitly passed on the command line.
+type Config struct {
+	SamplerType       string
+	SamplerParam      float64
+	AgentHostPort     string
+	CollectorEndpoint string
+	LogSpans          bool
+
+	fs *flag.FlagSet
+}
+
+// RegisterFlags binds the Jaeger configuration to fs: --jaeger-sampler-type,
+// --jaeger-sampler-param, --jaeger-agent-host-port, --jaeger-collector-endpoint
+// and --jaeger-log-spans. SamplerType supports const, probabilistic,
+// ratelimiting and remote, matching jaeger-client-go's sampler package.
+func RegisterFlags(fs *flag.FlagSet) *Config {
+	c := &Config{fs: fs}
+	fs.StringVar(&c.SamplerType, "jaeger-sampler-type", "", "jaeger sampler type: const, probabilistic, ratelimiting or remote (default: JAEGER_SAMPLER_TYPE or const)")
+	fs.Float64Var(&c.SamplerParam, "jaeger-sampler-param", 0, "jaeger sampler parameter (default: JAEGER_SAMPLER_PARAM or 1)")
+	fs.StringVar(&c.AgentHostPort, "jaeger-agent-host-port", "", "jaeger agent host:port (default: JAEGER_AGENT_HOST / JAEGER_AGENT_PORT)")
+	fs.StringVar(&c.CollectorEndpoint, "jaeger-collector-endpoint", "", "jaeger collector HTTP endpoint (default: JAEGER_ENDPOINT)")
+	fs.BoolVar(&c.LogSpans, "jaeger-log-spans", false, "log every span to stdout (default: JAEGER_REPORTER_LOG_SPANS)")
+	return c
+}
+
+// Init builds a Jaeger tracer for service. config.FromEnv() is read first so
+// JAEGER_* environment variables work out of the box; any flag in c that was
+// explicitly passed on the command line overrides the corresponding env
+// value.
+func Init(service string, c *Config) (opentracing.Tracer, io.Closer) {
+	cfg, err := config.FromEnv()
+	if err != nil {
+		panic(fmt.Sprintf("ERROR: cannot read Jaeger config from env: %v\n", err))
+	}
+	cfg.ServiceName = service
+	if cfg.Sampler == nil {
+		cfg.Sampler = &config.SamplerConfig{}
+	}
+	// config.FromEnv() leaves Type and Param at their zero values when the
+	// env vars are unset, so fall back to const / 1 here
+	if cfg.Sampler.Type == "" {
+		cfg.Sampler.Type = "const"
+	}
+	if os.Getenv("JAEGER_SAMPLER_PARAM") == "" {
+		cfg.Sampler.Param = 1
+	}
+	if c.SamplerType != "" {
+		cfg.Sampler.Type = c.SamplerType
+	}
+	if c.flagChanged("jaeger-sampler-param") {
+		cfg.Sampler.Param = c.SamplerParam
+	}
+	if cfg.Reporter == nil {
+		cfg.Reporter = &config.ReporterConfig{}
+	}
+	if c.AgentHostPort != "" {
+		cfg.Reporter.LocalAgentHostPort = c.AgentHostPort
+	}
+	if c.CollectorEndpoint != "" {
+		cfg.Reporter.CollectorEndpoint = c.CollectorEndpoint
+	}
+	if c.flagChanged("jaeger-log-spans") {
+		cfg.Reporter.LogSpans = c.LogSpans
+	}
+
+	tracer, closer, err := cfg.NewTracer(config.Logger(jaeger.StdLogger))
+	if err != nil {
+		panic(fmt.Sprintf("ERROR: cannot init Jaeger: %v\n", err))
+	}
+	return tracer, closer
+}
+
+// flagChanged reports whether name was explicitly set on the command line,
+// so a zero value (0, false) can still override what config.FromEnv() read.
+func (c *Config) flagChanged(name string) bool {
+	return c.fs != nil && c.fs.Changed(name)
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"fmt"
 	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
 	"github.com/grpc-ecosystem/go-grpc-middleware/tracing/opentracing"
 	"github.com/julienschmidt/httprouter"
@@ -12,30 +11,34 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	flag "github.com/spf13/pflag"
-	"github.com/uber/jaeger-client-go"
-	"github.com/uber/jaeger-client-go/config"
 	"google.golang.org/grpc"
-	"io"
+	"github.com/farhadf/micromovies2/pkg/tracing"
 	"github.com/farhadf/micromovies2/vault"
 	"github.com/farhadf/micromovies2/vault/pb"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 )
 
 func main() {
 	var (
-		httpAddr string
-		gRPCAddr string
-		console  bool
+		httpAddr     string
+		gRPCAddr     string
+		console      bool
+		drainTimeout time.Duration
 	)
 	flag.StringVarP(&httpAddr, "http", "H", ":8086", "http listen address")
 	flag.StringVarP(&gRPCAddr, "grpc", "G", ":8085", "gRPC listen address")
 	flag.BoolVarP(&console, "console", "c", false, "turns on pretty console logging")
+	flag.DurationVar(&drainTimeout, "drain-timeout", 10*time.Second, "time allowed to drain in-flight requests before shutdown")
+	jaegerConfig := tracing.RegisterFlags(flag.CommandLine)
 	flag.Parse()
-	ctx := context.Background()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 	//zerolog
 	logger := zerolog.New(os.Stderr).With().Timestamp().Caller().Logger()
 	//console pretty printing
@@ -65,34 +68,36 @@ func main() {
 	svc := vault.NewService()
 	svc = vault.LoggingMiddleware{logger, svc}
 	svc = vault.InstrumentingMiddleware{requestCount, requestLatency, countResult, svc}
-	errChan := make(chan error)
-	//os signal handling
-	go func() {
-		c := make(chan os.Signal, 1)
-		signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
-		errChan <- fmt.Errorf("%s", <-c)
-	}()
+	errChan := make(chan error, 2)
+	//os signal handling; canceling ctx lets everything downstream observe shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	//tracing
+	tracer, closer := tracing.Init("vaultService", jaegerConfig)
+	opentracing.SetGlobalTracer(tracer)
+	span := tracer.StartSpan("server")
+
 	hashEndpoint := vault.MakeHashEndpoint(svc)
+	hashEndpoint = vault.TracingMiddleware(tracer, "Hash")(hashEndpoint)
 	validateEndpoint := vault.MakeValidateEndpoint(svc)
+	validateEndpoint = vault.TracingMiddleware(tracer, "Validate")(validateEndpoint)
 	endpoints := vault.Endpoints{
 		HashEndpoint:     hashEndpoint,
 		ValidateEndpoint: validateEndpoint,
 	}
-	//tracing
-	tracer, closer := initJaeger("vaultService")
-	defer closer.Close()
-	opentracing.SetGlobalTracer(tracer)
-	span := tracer.StartSpan("server")
-	defer span.Finish()
+	//httprouter initialization
+	router := httprouter.New()
+	//handler will be used for net/http handle compatibility
+	router.Handler("GET", "/metrics", promhttp.Handler())
+	//JSON transport for HashEndpoint/ValidateEndpoint, mirroring the gRPC transport below
+	endpoints.Register(router)
+	httpServer := &http.Server{Addr: httpAddr, Handler: router}
 	// HTTP transport
 	go func() {
-		//httprouter initialization
-		router := httprouter.New()
-		//handler will be used for net/http handle compatibility
-		router.Handler("GET", "/metrics", promhttp.Handler())
-		errChan <- http.ListenAndServe(httpAddr, router)
+		errChan <- httpServer.ListenAndServe()
 	}()
 	// GRPC transport
+	gRPCServer := grpc.NewServer(grpc.UnaryInterceptor(grpc_opentracing.UnaryServerInterceptor()))
 	go func() {
 		listener, err := net.Listen("tcp", gRPCAddr)
 		if err != nil {
@@ -102,30 +107,38 @@ func main() {
 		logger.Info().Str("grpc:", gRPCAddr).Msg("")
 		handler := vault.NewGRPCServer(ctx, endpoints)
 		//add grpc_opentracing interceptor for server
-		gRPCServer := grpc.NewServer(grpc.UnaryInterceptor(grpc_opentracing.UnaryServerInterceptor()))
 		pb.RegisterVaultServer(gRPCServer, handler)
 		errChan <- gRPCServer.Serve(listener)
 	}()
 
 	logger.Info().Msg(gRPCAddr)
-	logger.Fatal().Err(<-errChan).Msg("")
-}
-
-// initJaeger returns an instance of Jaeger Tracer that samples 100% of traces and logs all spans to stdout.
-func initJaeger(service string) (opentracing.Tracer, io.Closer) {
-	cfg := &config.Configuration{
-		Sampler: &config.SamplerConfig{
-			Type:  "const",
-			Param: 1,
-		},
-		Reporter: &config.ReporterConfig{
-			LogSpans: false,
-		},
-		ServiceName: service,
-	}
-	tracer, closer, err := cfg.NewTracer(config.Logger(jaeger.StdLogger))
-	if err != nil {
-		panic(fmt.Sprintf("ERROR: cannot init Jaeger: %v\n", err))
+	select {
+	case err := <-errChan:
+		logger.Error().Err(err).Msg("server error, shutting down")
+	case sig := <-sigChan:
+		logger.Info().Str("signal", sig.String()).Msg("received shutdown signal")
 	}
-	return tracer, closer
+	cancel()
+
+	//drain in-flight requests before tearing anything else down
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer shutdownCancel()
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			logger.Error().Err(err).Msg("http server shutdown error")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		gRPCServer.GracefulStop()
+	}()
+	wg.Wait()
+
+	//finish the top-level span before closing the reporter it flushes through
+	span.Finish()
+	closer.Close()
+	logger.Info().Msg("shutdown complete")
 }
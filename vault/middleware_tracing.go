@@ -0,0 +1,28 @@
+package vault
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+// TracingMiddleware returns an endpoint.Middleware that starts a child span
+// named after method for every invocation of the wrapped endpoint, tagging
+// the span with the outcome so a single request can be followed across
+// api-gateway -> vault gRPC -> downstream services in Jaeger.
+func TracingMiddleware(tracer opentracing.Tracer, method string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			span, ctx := opentracing.StartSpanFromContextWithTracer(ctx, tracer, method)
+			defer span.Finish()
+			span.SetTag("method", method)
+			response, err := next(ctx, request)
+			if err != nil {
+				span.SetTag("error", true)
+				span.LogKV("event", "error", "message", err.Error())
+			}
+			return response, err
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// Register wires the HTTP/JSON transport onto r, exposing the same
+// HashEndpoint/ValidateEndpoint go-kit endpoints that are served over gRPC so
+// that clients such as apigateway can call vault without a gRPC stub.
+func (e Endpoints) Register(r *httprouter.Router) {
+	r.Handle("POST", "/v1/hash", e.HandleHashPost)
+	r.Handle("POST", "/v1/validate", e.HandleValidatePost)
+}
+
+func (e Endpoints) HandleHashPost(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	req, err := decodeHashRequest(r.Context(), r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+	resp, err := e.HashEndpoint(r.Context(), req)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err)
+		return
+	}
+	respondSuccess(w, resp)
+}
+
+func (e Endpoints) HandleValidatePost(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	req, err := decodeValidateRequest(r.Context(), r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+	resp, err := e.ValidateEndpoint(r.Context(), req)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err)
+		return
+	}
+	respondSuccess(w, resp)
+}
+
+func decodeHashRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var req hashRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	return req, err
+}
+
+func decodeValidateRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var req validateRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	return req, err
+}
+
+// respondError writes err in the same canonical JSON envelope apigateway
+// uses for its own HTTP transport.
+func respondError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":       err.Error(),
+		"status_code": code,
+		"status_text": http.StatusText(code),
+	})
+}
+
+func respondSuccess(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(data)
+}